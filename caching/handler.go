@@ -3,6 +3,7 @@ package caching
 import (
 	"fmt"
 	routing "github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/studio-b12/ozzo-routing/file"
 	"strings"
 	"time"
 )
@@ -32,6 +33,25 @@ type Options struct {
 	MustUnderstand  bool
 	NoTransform     bool
 	Immutable       bool
+	// StaleWhileRevalidate is the "stale-while-revalidate" duration as defined
+	// in RFC 5861 Section 3: a stale response may be served for this long
+	// while a revalidation request is made in the background.
+	StaleWhileRevalidate time.Duration
+	// StaleIfError is the "stale-if-error" duration as defined in RFC 5861
+	// Section 4: a stale response may be served for this long if revalidation
+	// fails, e.g. because the origin is unreachable.
+	StaleIfError time.Duration
+	// NoCacheFields emits the qualified "no-cache" form from RFC 7234 Section
+	// 5.2.2.2, e.g. NoCacheFields{"Set-Cookie"} renders as
+	// `no-cache="Set-Cookie"`: the given response header fields must be
+	// revalidated with the origin before reuse, while the rest of the
+	// response may still be served from cache.
+	NoCacheFields []string
+	// PrivateFields emits the qualified "private" form from RFC 7234 Section
+	// 5.2.2.6, e.g. PrivateFields{"Authorization"} renders as
+	// `private="Authorization"`: the given response header fields must not be
+	// stored by shared caches, while the rest of the response may be.
+	PrivateFields []string
 }
 
 func (t Options) BuildHeaderValues() string {
@@ -53,6 +73,10 @@ func (t Options) BuildHeaderValues() string {
 		fmt.Fprint(&sb, "no-cache, ")
 	}
 
+	if len(t.NoCacheFields) > 0 {
+		fmt.Fprintf(&sb, "no-cache=%q, ", strings.Join(t.NoCacheFields, ", "))
+	}
+
 	if t.NoStore {
 		fmt.Fprint(&sb, "no-store, ")
 	}
@@ -77,6 +101,18 @@ func (t Options) BuildHeaderValues() string {
 		fmt.Fprint(&sb, "immutable, ")
 	}
 
+	if len(t.PrivateFields) > 0 {
+		fmt.Fprintf(&sb, "private=%q, ", strings.Join(t.PrivateFields, ", "))
+	}
+
+	if t.StaleWhileRevalidate != 0 {
+		fmt.Fprintf(&sb, "stale-while-revalidate=%d, ", int64(t.StaleWhileRevalidate.Round(time.Second).Seconds()))
+	}
+
+	if t.StaleIfError != 0 {
+		fmt.Fprintf(&sb, "stale-if-error=%d, ", int64(t.StaleIfError.Round(time.Second).Seconds()))
+	}
+
 	v := sb.String()
 	if len(v) < 2 {
 		return ""
@@ -124,3 +160,37 @@ func NoCache() routing.Handler {
 func NoStore() routing.Handler {
 	return Handler(Options{NoStore: true})
 }
+
+// PerRoute returns a routing.Handler which sets the "Cache-Control" header
+// to the value computed by f for the current request, allowing the policy
+// to vary per route or per request (e.g. a long max-age for fingerprinted
+// assets and a short one for HTML).
+func PerRoute(f func(*routing.Context) Options) routing.Handler {
+	return func(c *routing.Context) error {
+		c.Response.Header().Set("Cache-Control", f(c).BuildHeaderValues())
+		return nil
+	}
+}
+
+// fingerprintMaxAge is the max-age ForFile applies to requests whose URL
+// carries a file.ServerOptions.AssetFingerprint suffix.
+const fingerprintMaxAge = 365 * 24 * time.Hour
+
+// ForFile returns a routing.Handler that sets the "Cache-Control" header
+// appropriately for a file served by file.Server/file.Content configured
+// with options: requests whose URL path carries an asset fingerprint (see
+// file.ServerOptions.AssetFingerprint) get "public, max-age=<1y>, immutable";
+// other requests are left untouched.
+func ForFile(options file.ServerOptions) routing.Handler {
+	return func(c *routing.Context) error {
+		if !options.AssetFingerprint || !file.HasFingerprint(c.Request.URL.Path) {
+			return nil
+		}
+		c.Response.Header().Set("Cache-Control", Options{
+			Access:    AccessPublic,
+			MaxAge:    fingerprintMaxAge,
+			Immutable: true,
+		}.BuildHeaderValues())
+		return nil
+	}
+}
@@ -1,8 +1,12 @@
 package caching
 
 import (
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/studio-b12/ozzo-routing/file"
 )
 
 func TestOptions_BuildHeaderValues(t1 *testing.T) {
@@ -41,21 +45,44 @@ func TestOptions_BuildHeaderValues(t1 *testing.T) {
 			fields: Options{},
 			want:   "",
 		},
+		{
+			name: "stale",
+			fields: Options{
+				Access:               AccessPublic,
+				MaxAge:               time.Minute,
+				StaleWhileRevalidate: 30 * time.Second,
+				StaleIfError:         time.Hour,
+			},
+			want: "public, max-age=60, stale-while-revalidate=30, stale-if-error=3600",
+		},
+		{
+			name: "qualified fields",
+			fields: Options{
+				Access:        AccessPublic,
+				NoCacheFields: []string{"Set-Cookie"},
+				PrivateFields: []string{"Authorization", "X-Auth-Token"},
+			},
+			want: `public, no-cache="Set-Cookie", private="Authorization, X-Auth-Token"`,
+		},
 	}
 
 	for _, tt := range tests {
 		t1.Run(tt.name, func(t1 *testing.T) {
 			t := Options{
-				Access:          tt.fields.Access,
-				MaxAge:          tt.fields.MaxAge,
-				SMaxAge:         tt.fields.SMaxAge,
-				NoCache:         tt.fields.NoCache,
-				NoStore:         tt.fields.NoStore,
-				MustRevalidate:  tt.fields.MustRevalidate,
-				ProxyRevalidate: tt.fields.ProxyRevalidate,
-				MustUnderstand:  tt.fields.MustUnderstand,
-				NoTransform:     tt.fields.NoTransform,
-				Immutable:       tt.fields.Immutable,
+				Access:               tt.fields.Access,
+				MaxAge:               tt.fields.MaxAge,
+				SMaxAge:              tt.fields.SMaxAge,
+				NoCache:              tt.fields.NoCache,
+				NoStore:              tt.fields.NoStore,
+				MustRevalidate:       tt.fields.MustRevalidate,
+				ProxyRevalidate:      tt.fields.ProxyRevalidate,
+				MustUnderstand:       tt.fields.MustUnderstand,
+				NoTransform:          tt.fields.NoTransform,
+				Immutable:            tt.fields.Immutable,
+				StaleWhileRevalidate: tt.fields.StaleWhileRevalidate,
+				StaleIfError:         tt.fields.StaleIfError,
+				NoCacheFields:        tt.fields.NoCacheFields,
+				PrivateFields:        tt.fields.PrivateFields,
 			}
 			if got := t.BuildHeaderValues(); got != tt.want {
 				t1.Errorf("BuildHeaderValues() failed\n"+
@@ -65,3 +92,81 @@ func TestOptions_BuildHeaderValues(t1 *testing.T) {
 		})
 	}
 }
+
+func newHandlerTestContext(path string) (*routing.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", path, nil)
+	return routing.NewContext(rec, req), rec
+}
+
+func TestPerRoute(t *testing.T) {
+	calls := 0
+	handler := PerRoute(func(c *routing.Context) Options {
+		calls++
+		if c.Request.URL.Path == "/private" {
+			return Options{Access: AccessPrivate, NoStore: true}
+		}
+		return Options{Access: AccessPublic, MaxAge: time.Minute}
+	})
+
+	c1, rec1 := newHandlerTestContext("/public")
+	if err := handler(c1); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	if got, want := rec1.Header().Get("Cache-Control"), "public, max-age=60"; got != want {
+		t.Errorf("Cache-Control = %q, want %q", got, want)
+	}
+
+	c2, rec2 := newHandlerTestContext("/private")
+	if err := handler(c2); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	if got, want := rec2.Header().Get("Cache-Control"), "private, no-store"; got != want {
+		t.Errorf("Cache-Control = %q, want %q", got, want)
+	}
+
+	if calls != 2 {
+		t.Errorf("f was called %d times, want 2 (once per request)", calls)
+	}
+}
+
+func TestForFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		options file.ServerOptions
+		path    string
+		want    string
+	}{
+		{
+			name:    "fingerprinted path with AssetFingerprint enabled",
+			options: file.ServerOptions{AssetFingerprint: true},
+			path:    "/js/app.abcdef12.js",
+			want:    "public, max-age=31536000, immutable",
+		},
+		{
+			name:    "fingerprinted path with AssetFingerprint disabled is a no-op",
+			options: file.ServerOptions{AssetFingerprint: false},
+			path:    "/js/app.abcdef12.js",
+			want:    "",
+		},
+		{
+			name:    "non-fingerprinted path is a no-op",
+			options: file.ServerOptions{AssetFingerprint: true},
+			path:    "/js/app.js",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := ForFile(tt.options)
+			c, rec := newHandlerTestContext(tt.path)
+			if err := handler(c); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// ArchiveFormat identifies the container format used by Archive.
+type ArchiveFormat string
+
+const (
+	ArchiveFormatTar = ArchiveFormat("tar")
+	ArchiveFormatZip = ArchiveFormat("zip")
+)
+
+// ArchiveOptions defines the possible options for the Archive handler.
+type ArchiveOptions struct {
+	ServerOptions
+	// Format forces the archive container format. If empty, it is negotiated
+	// against the request's "Accept" header ("application/zip" or
+	// "application/x-tar"), defaulting to tar.
+	Format ArchiveFormat
+	// Name is the base file name, without extension, used for the
+	// "Content-Disposition" header. Defaults to "archive".
+	Name string
+}
+
+// archiveEntry is a single file included in an archive produced by Archive.
+type archiveEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Archive returns a handler that streams a tar or zip archive of the given
+// files and directories, resolved through the same ServerOptions.FS/RootPath
+// machinery used by Server. Directories are included recursively. The
+// ServerOptions.Allow and ServerOptions.SignedURL options, if set, are
+// applied exactly as they are by Server/Content: Allow is consulted for
+// every individual file before it is added to the archive, and SignedURL
+// requires every request to carry a valid signature produced by Sign.
+// ServerOptions.ETag, AssetFingerprint, AutoIndex, IndexFile and
+// CatchAllFile do not apply to archives and are ignored.
+//
+// The response carries a deterministic "ETag" computed from the negotiated
+// format and the sorted (path, size, modification time) of every included
+// file, so "If-None-Match" is served without re-building the archive. A zip
+// archive is always written uncompressed ("Store"), which, together with the
+// tar/zip container layouts being fully determined by entry names and sizes,
+// lets Content-Length be computed up front without reading any file content.
+// This makes plain "GET" and "HEAD" requests cheap: a "HEAD" without a
+// "Range" header never reads any file content, and "GET" streams the archive
+// straight to the response without buffering. A "Range" request is the one case that needs random access to
+// the generated bytes, so it is the one case built into memory, on demand,
+// for that request only.
+func Archive(paths []string, opts ArchiveOptions) routing.Handler {
+	options := getServerOptions([]ServerOptions{opts.ServerOptions})
+	dir := fileSystem(options)
+
+	name := opts.Name
+	if name == "" {
+		name = "archive"
+	}
+
+	return func(c *routing.Context) error {
+		if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+			return routing.NewHTTPError(http.StatusMethodNotAllowed)
+		}
+
+		if options.SignedURL != nil {
+			if err := checkSignedURL(c, c.Request.URL.Path, *options.SignedURL); err != nil {
+				return err
+			}
+		}
+
+		entries, err := collectArchiveEntries(c, dir, paths, options.Allow)
+		if err != nil {
+			return routing.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+
+		format := opts.Format
+		if format == "" {
+			format = negotiateArchiveFormat(c.Request.Header.Get("Accept"))
+		}
+		fileName := fmt.Sprintf("%s.%s", name, archiveExtension(format))
+		etag := archiveETag(entries, format)
+		contentType := "application/x-tar"
+		if format == ArchiveFormatZip {
+			contentType = "application/zip"
+		}
+
+		// The archive varies with the negotiated format, so a cached response
+		// for one representation must not be reused for another.
+		c.Response.Header().Set("Vary", "Accept")
+		c.Response.Header().Set("ETag", etag)
+		c.Response.Header().Set("Content-Type", contentType)
+		c.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+		if match := c.Request.Header.Get("If-None-Match"); match != "" && match == etag {
+			c.Response.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+
+		if c.Request.Header.Get("Range") != "" {
+			// Serving a range requires random access to the generated bytes,
+			// which a single forward pass over the response writer can't
+			// provide, so build the archive into memory for this request.
+			var buf bytes.Buffer
+			if err := writeArchive(&buf, dir, entries, format); err != nil {
+				return routing.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			http.ServeContent(c.Response, c.Request, fileName, time.Time{}, bytes.NewReader(buf.Bytes()))
+			return nil
+		}
+
+		// The container layout is fully determined by entry names and sizes,
+		// so the exact byte length can be computed without reading any file
+		// content, letting HEAD answer without building the archive and GET
+		// report a size while still streaming.
+		if length, err := archiveContentLength(entries, format); err == nil {
+			c.Response.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+		}
+
+		if c.Request.Method == "HEAD" {
+			return nil
+		}
+
+		if err := writeArchive(c.Response, dir, entries, format); err != nil {
+			return routing.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	}
+}
+
+// writeArchive streams entries to w in the given format.
+func writeArchive(w io.Writer, dir http.FileSystem, entries []archiveEntry, format ArchiveFormat) error {
+	if format == ArchiveFormatZip {
+		return writeZipArchive(w, dir, entries)
+	}
+	return writeTarArchive(w, dir, entries)
+}
+
+// archiveContentLength returns the exact byte length of the archive that
+// writeArchive would produce for entries in format, without reading any
+// file's actual content. Both tar and zip (written with the Store method,
+// see addZipEntry) lay out their headers and padding purely from entry names
+// and sizes, so replaying the same write against sizeOnlyFS, which returns
+// entries.size placeholder bytes instead of real file content, yields an
+// identical byte count to the real thing.
+func archiveContentLength(entries []archiveEntry, format ArchiveFormat) (int64, error) {
+	var cw countingWriter
+	if err := writeArchive(&cw, sizeOnlyFS(entries), entries, format); err != nil {
+		return 0, err
+	}
+	return cw.n, nil
+}
+
+// countingWriter discards everything written to it, keeping only a running
+// total of the bytes seen.
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// sizeOnlyFS opens each archiveEntry's path as a file that yields size
+// unspecified bytes without holding them in memory, for use where only the
+// resulting archive's length matters, not its content.
+type sizeOnlyFS []archiveEntry
+
+func (fs sizeOnlyFS) Open(name string) (http.File, error) {
+	for _, e := range fs {
+		if e.path == name {
+			return &sizeOnlyFile{remaining: e.size}, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// sizeOnlyFile implements http.File, reporting a fixed number of bytes of
+// unspecified content. It exists solely so archiveContentLength can drive
+// writeArchive to compute a byte count.
+type sizeOnlyFile struct{ remaining int64 }
+
+func (f *sizeOnlyFile) Read(p []byte) (int, error) {
+	if f.remaining <= 0 {
+		return 0, io.EOF
+	}
+	n := int64(len(p))
+	if n > f.remaining {
+		n = f.remaining
+	}
+	f.remaining -= n
+	return int(n), nil
+}
+
+func (f *sizeOnlyFile) Close() error { return nil }
+
+func (f *sizeOnlyFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.New("file: sizeOnlyFile does not support Seek")
+}
+
+func (f *sizeOnlyFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errors.New("file: sizeOnlyFile does not support Readdir")
+}
+
+func (f *sizeOnlyFile) Stat() (os.FileInfo, error) {
+	return nil, errors.New("file: sizeOnlyFile does not support Stat")
+}
+
+// collectArchiveEntries resolves paths (recursing into directories) into the
+// sorted, deduplicated list of files to include in the archive, filtering
+// out any rejected by allow.
+func collectArchiveEntries(c *routing.Context, dir http.FileSystem, paths []string, allow func(*routing.Context, string) bool) ([]archiveEntry, error) {
+	seen := make(map[string]bool)
+	var entries []archiveEntry
+
+	for _, p := range paths {
+		if containsDotDot(p) {
+			continue
+		}
+		found, err := walkArchivePath(dir, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range found {
+			if allow != nil && !allow(c, e.path) {
+				continue
+			}
+			if seen[e.path] {
+				continue
+			}
+			seen[e.path] = true
+			entries = append(entries, e)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.New("file: no files to archive")
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+	return entries, nil
+}
+
+// walkArchivePath opens root and, if it is a directory, recurses into it,
+// returning every regular file found.
+func walkArchivePath(dir http.FileSystem, root string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+
+	var walk func(p string) error
+	walk = func(p string) error {
+		f, err := dir.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		st, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		if !st.IsDir() {
+			entries = append(entries, archiveEntry{path: p, size: st.Size(), modTime: st.ModTime()})
+			return nil
+		}
+
+		infos, err := f.Readdir(-1)
+		if err != nil {
+			return err
+		}
+		sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+		for _, info := range infos {
+			if err := walk(path.Join(p, info.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// archiveETag computes a strong ETag from format and the sorted
+// (path, size, modTime) tuple of every entry, without reading any file
+// content. format is included so that the two representations negotiated
+// for the same set of files never share an ETag.
+func archiveETag(entries []archiveEntry, format ArchiveFormat) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", format)
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", e.path, e.size, e.modTime.UnixNano())
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+func archiveExtension(format ArchiveFormat) string {
+	if format == ArchiveFormatZip {
+		return "zip"
+	}
+	return "tar"
+}
+
+// negotiateArchiveFormat picks an ArchiveFormat from the request's "Accept"
+// header, defaulting to tar.
+func negotiateArchiveFormat(accept string) ArchiveFormat {
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if semi := strings.IndexByte(part, ';'); semi >= 0 {
+			part = part[:semi]
+		}
+		switch part {
+		case "application/zip":
+			return ArchiveFormatZip
+		case "application/x-tar", "application/tar":
+			return ArchiveFormatTar
+		}
+	}
+	return ArchiveFormatTar
+}
+
+func writeTarArchive(w io.Writer, dir http.FileSystem, entries []archiveEntry) error {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		if err := addTarEntry(tw, dir, e); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func addTarEntry(tw *tar.Writer, dir http.FileSystem, e archiveEntry) error {
+	f, err := dir.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hdr := &tar.Header{
+		Name:    strings.TrimPrefix(e.path, "/"),
+		Size:    e.size,
+		Mode:    0644,
+		ModTime: e.modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func writeZipArchive(w io.Writer, dir http.FileSystem, entries []archiveEntry) error {
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		if err := addZipEntry(zw, dir, e); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func addZipEntry(zw *zip.Writer, dir http.FileSystem, e archiveEntry) error {
+	f, err := dir.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Store rather than Deflate: an entry's on-disk size then equals its
+	// compressed size, which is what lets archiveContentLength compute an
+	// exact Content-Length without reading (let alone compressing) content.
+	fh := &zip.FileHeader{
+		Name:     strings.TrimPrefix(e.path, "/"),
+		Method:   zip.Store,
+		Modified: e.modTime,
+	}
+	entryWriter, err := zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entryWriter, f)
+	return err
+}
@@ -0,0 +1,245 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestArchive_TarAndZip(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"docs/a.txt":     {Data: []byte("aaa"), ModTime: modTime},
+		"docs/sub/b.txt": {Data: []byte("bbb"), ModTime: modTime},
+	}
+	handler := Archive([]string{"/docs"}, ArchiveOptions{ServerOptions: ServerOptions{FS: fsys}})
+
+	t.Run("tar by default", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/download", nil)
+		c, rec := newTestContext(req)
+
+		if err := handler(c); err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+
+		names := map[string]bool{}
+		tr := tar.NewReader(rec.Body)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("tar.Next() failed: %v", err)
+			}
+			names[hdr.Name] = true
+		}
+		if !names["docs/a.txt"] || !names["docs/sub/b.txt"] {
+			t.Errorf("tar archive missing entries: %v", names)
+		}
+	})
+
+	t.Run("zip via Accept negotiation", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/download", nil)
+		req.Header.Set("Accept", "application/zip")
+		c, rec := newTestContext(req)
+
+		if err := handler(c); err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if err != nil {
+			t.Fatalf("zip.NewReader() failed: %v", err)
+		}
+		names := map[string]bool{}
+		for _, f := range zr.File {
+			names[f.Name] = true
+		}
+		if !names["docs/a.txt"] || !names["docs/sub/b.txt"] {
+			t.Errorf("zip archive missing entries: %v", names)
+		}
+	})
+
+	t.Run("HEAD returns no body", func(t *testing.T) {
+		req := httptest.NewRequest("HEAD", "/download", nil)
+		c, rec := newTestContext(req)
+
+		if err := handler(c); err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("HEAD response body length = %d, want 0", rec.Body.Len())
+		}
+	})
+
+	t.Run("disallowed method rejected", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/download", nil)
+		c, _ := newTestContext(req)
+
+		if err := handler(c); err == nil {
+			t.Error("handler succeeded, want method-not-allowed error")
+		}
+	})
+}
+
+func TestArchive_ETagVariesByFormat(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/a.txt": {Data: []byte("aaa"), ModTime: time.Unix(1700000000, 0)},
+	}
+	handler := Archive([]string{"/docs"}, ArchiveOptions{ServerOptions: ServerOptions{FS: fsys}})
+
+	etagFor := func(accept string) string {
+		req := httptest.NewRequest("GET", "/download", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		c, rec := newTestContext(req)
+		if err := handler(c); err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		return rec.Header().Get("ETag")
+	}
+
+	tarETag := etagFor("")
+	zipETag := etagFor("application/zip")
+	if tarETag == "" || zipETag == "" {
+		t.Fatal("expected non-empty ETags")
+	}
+	if tarETag == zipETag {
+		t.Errorf("tar and zip representations share an ETag: %v", tarETag)
+	}
+}
+
+func TestArchive_IfNoneMatch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/a.txt": {Data: []byte("aaa"), ModTime: time.Unix(1700000000, 0)},
+	}
+	handler := Archive([]string{"/docs"}, ArchiveOptions{ServerOptions: ServerOptions{FS: fsys}})
+
+	req := httptest.NewRequest("GET", "/download", nil)
+	c, rec := newTestContext(req)
+	if err := handler(c); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	etag := rec.Header().Get("ETag")
+
+	req2 := httptest.NewRequest("GET", "/download", nil)
+	req2.Header.Set("If-None-Match", etag)
+	c2, rec2 := newTestContext(req2)
+	if err := handler(c2); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	if rec2.Code != 304 {
+		t.Errorf("status = %d, want 304", rec2.Code)
+	}
+}
+
+func TestArchive_ContentLength(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"docs/a.txt":     {Data: []byte("aaa"), ModTime: modTime},
+		"docs/sub/b.txt": {Data: []byte("bbbbbb"), ModTime: modTime},
+	}
+	handler := Archive([]string{"/docs"}, ArchiveOptions{ServerOptions: ServerOptions{FS: fsys}})
+
+	tests := []struct {
+		name   string
+		accept string
+	}{
+		{"tar", ""},
+		{"zip", "application/zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			get := httptest.NewRequest("GET", "/download", nil)
+			if tt.accept != "" {
+				get.Header.Set("Accept", tt.accept)
+			}
+			c, rec := newTestContext(get)
+			if err := handler(c); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+
+			declared := rec.Header().Get("Content-Length")
+			if declared == "" {
+				t.Fatal("Content-Length header not set")
+			}
+			if declared != strconv.Itoa(rec.Body.Len()) {
+				t.Errorf("Content-Length = %s, want %d (actual body size)", declared, rec.Body.Len())
+			}
+
+			head := httptest.NewRequest("HEAD", "/download", nil)
+			if tt.accept != "" {
+				head.Header.Set("Accept", tt.accept)
+			}
+			hc, hrec := newTestContext(head)
+			if err := handler(hc); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			if got := hrec.Header().Get("Content-Length"); got != declared {
+				t.Errorf("HEAD Content-Length = %s, want %s (same as GET)", got, declared)
+			}
+			if hrec.Body.Len() != 0 {
+				t.Errorf("HEAD response body length = %d, want 0", hrec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestArchive_Range(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"docs/a.txt":     {Data: []byte("aaaaaaaaaa"), ModTime: modTime},
+		"docs/sub/b.txt": {Data: []byte("bbbbbbbbbb"), ModTime: modTime},
+	}
+	handler := Archive([]string{"/docs"}, ArchiveOptions{ServerOptions: ServerOptions{FS: fsys}})
+
+	full := httptest.NewRequest("GET", "/download", nil)
+	c, rec := newTestContext(full)
+	if err := handler(c); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	fullBody := rec.Body.Bytes()
+
+	ranged := httptest.NewRequest("GET", "/download", nil)
+	ranged.Header.Set("Range", "bytes=0-9")
+	rc, rrec := newTestContext(ranged)
+	if err := handler(rc); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	if rrec.Code != 206 {
+		t.Fatalf("status = %d, want 206", rrec.Code)
+	}
+	wantRange := fmt.Sprintf("bytes 0-9/%d", len(fullBody))
+	if got := rrec.Header().Get("Content-Range"); got != wantRange {
+		t.Errorf("Content-Range = %q, want %q", got, wantRange)
+	}
+	if got := rrec.Body.Bytes(); !bytes.Equal(got, fullBody[:10]) {
+		t.Errorf("ranged body = %v, want %v", got, fullBody[:10])
+	}
+}
+
+func TestArchiveETag(t *testing.T) {
+	entries := []archiveEntry{
+		{path: "a.txt", size: 3, modTime: time.Unix(1700000000, 0)},
+	}
+
+	tarETag := archiveETag(entries, ArchiveFormatTar)
+	zipETag := archiveETag(entries, ArchiveFormatZip)
+	if tarETag == zipETag {
+		t.Errorf("archiveETag() did not vary by format: %v", tarETag)
+	}
+	if archiveETag(entries, ArchiveFormatTar) != tarETag {
+		t.Error("archiveETag() not deterministic")
+	}
+}
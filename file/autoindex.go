@@ -0,0 +1,93 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// indexEntry describes a single file or subdirectory listed by AutoIndex.
+type indexEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	IsDir   bool      `json:"isDir"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// serveAutoIndex renders a directory listing for dirFile, which must already
+// be opened on path. The response is negotiated via the "Accept" request
+// header between an HTML table (the default) and a JSON array.
+func serveAutoIndex(c *routing.Context, dirFile http.File, path string) error {
+	infos, err := dirFile.Readdir(-1)
+	if err != nil {
+		return routing.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	entries := make([]indexEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = indexEntry{
+			Name:    info.Name(),
+			Size:    info.Size(),
+			IsDir:   info.IsDir(),
+			ModTime: info.ModTime(),
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	if acceptsJSON(c.Request.Header.Get("Accept")) {
+		c.Response.Header().Set("Content-Type", "application/json; charset=utf-8")
+		return json.NewEncoder(c.Response).Encode(entries)
+	}
+
+	c.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = c.Response.Write([]byte(renderIndexHTML(path, entries)))
+	return err
+}
+
+// acceptsJSON reports whether accept prefers "application/json" over
+// "text/html", based on the order types appear in the header.
+func acceptsJSON(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if semi := strings.IndexByte(part, ';'); semi >= 0 {
+			part = part[:semi]
+		}
+		switch part {
+		case "application/json":
+			return true
+		case "text/html", "*/*", "":
+			return false
+		}
+	}
+	return false
+}
+
+// renderIndexHTML renders a simple directory listing page for the given
+// path and entries.
+func renderIndexHTML(path string, entries []indexEntry) string {
+	var sb strings.Builder
+	title := html.EscapeString(path)
+	fmt.Fprintf(&sb, "<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>Index of %s</title></head>\n<body>\n", title)
+	fmt.Fprintf(&sb, "<h1>Index of %s</h1>\n<table>\n<tr><th>Name</th><th>Size</th><th>Last Modified</th></tr>\n", title)
+
+	for _, e := range entries {
+		name := e.Name
+		href := url.PathEscape(e.Name)
+		if e.IsDir {
+			name += "/"
+			href += "/"
+		}
+		fmt.Fprintf(&sb, "<tr><td><a href=\"%s\">%s</a></td><td>%d</td><td>%s</td></tr>\n",
+			href, html.EscapeString(name), e.Size, e.ModTime.Format(time.RFC1123))
+	}
+
+	sb.WriteString("</table>\n</body>\n</html>\n")
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package file
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAcceptsJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   bool
+	}{
+		{"empty defaults to html", "", false},
+		{"explicit json", "application/json", true},
+		{"explicit html", "text/html", false},
+		{"wildcard defaults to html", "*/*", false},
+		{"json preferred when listed first", "application/json, text/html", true},
+		{"html preferred when listed first", "text/html, application/json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acceptsJSON(tt.accept); got != tt.want {
+				t.Errorf("acceptsJSON(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_AutoIndex(t *testing.T) {
+	fsys := fstest.MapFS{
+		"static/b.txt": {Data: []byte("b")},
+		"static/a.txt": {Data: []byte("a")},
+	}
+
+	handler := Server(PathMap{"/files": "/static"}, ServerOptions{FS: fsys, AutoIndex: true})
+
+	t.Run("html listing", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/files", nil)
+		c, rec := newTestContext(req)
+
+		if err := handler(c); err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		if rec.Code != 0 && rec.Code != 200 {
+			t.Errorf("status = %d, want 200", rec.Code)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "a.txt") || !strings.Contains(body, "b.txt") {
+			t.Errorf("listing missing entries, got: %s", body)
+		}
+	})
+
+	t.Run("json listing", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/files", nil)
+		req.Header.Set("Accept", "application/json")
+		c, rec := newTestContext(req)
+
+		if err := handler(c); err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		var entries []indexEntry
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("invalid JSON response: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("got %d entries, want 2", len(entries))
+		}
+		if entries[0].Name != "a.txt" || entries[1].Name != "b.txt" {
+			t.Errorf("entries not sorted by name: %+v", entries)
+		}
+	})
+
+	t.Run("no index and no autoindex 404s", func(t *testing.T) {
+		handler := Server(PathMap{"/files": "/static"}, ServerOptions{FS: fsys})
+		req := httptest.NewRequest("GET", "/files", nil)
+		c, _ := newTestContext(req)
+
+		if err := handler(c); err == nil {
+			t.Fatal("handler succeeded, want 404 error")
+		}
+	})
+}
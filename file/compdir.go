@@ -1,23 +1,65 @@
 package file
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
+// errIdentityNotAcceptable is returned by compressionDir.Open when none of
+// the negotiated encodings could be opened and the client has explicitly
+// forbidden the unencoded ("identity") representation via Accept-Encoding.
+var errIdentityNotAcceptable = errors.New("file: identity encoding not acceptable")
+
+// compressionDir opens pre-compressed variants of a file (e.g. "style.css.br")
+// before falling back to the unencoded file. encodings is expected to already
+// be ordered from most to least preferred.
 type compressionDir struct {
-	dir       http.Dir
-	encodings []Encoding
+	dir             http.FileSystem
+	encodings       []Encoding
+	identityAllowed bool
 }
 
 func (t compressionDir) Open(path string) (f http.File, enc Encoding, err error) {
+	rawModTime, haveRawModTime := t.rawModTime(path)
+
 	for _, enc = range t.encodings {
 		f, err = t.dir.Open(fmt.Sprintf("%s.%s", path, enc))
-		if err == nil {
-			return f, enc, nil
+		if err != nil {
+			continue
+		}
+		// Skip pre-compressed variants that are older than the raw file, as
+		// they presumably no longer reflect its content.
+		if haveRawModTime {
+			if st, statErr := f.Stat(); statErr == nil && st.ModTime().Before(rawModTime) {
+				f.Close()
+				continue
+			}
 		}
+		return f, enc, nil
+	}
+
+	if !t.identityAllowed {
+		return nil, "", errIdentityNotAcceptable
 	}
 
 	f, err = t.dir.Open(path)
 	return f, "", err
 }
+
+func (t compressionDir) rawModTime(path string) (modTime time.Time, ok bool) {
+	if len(t.encodings) == 0 {
+		return time.Time{}, false
+	}
+	raw, err := t.dir.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer raw.Close()
+	st, err := raw.Stat()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return st.ModTime(), true
+}
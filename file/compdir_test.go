@@ -0,0 +1,89 @@
+package file
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCompressionDir_Open(t *testing.T) {
+	older := time.Unix(1000, 0)
+	newer := time.Unix(2000, 0)
+
+	fsys := fstest.MapFS{
+		"style.css":    {Data: []byte("raw"), ModTime: newer},
+		"style.css.br": {Data: []byte("brotli"), ModTime: newer},
+		"style.css.gz": {Data: []byte("gzip"), ModTime: older},
+		"noraw.css.br": {Data: []byte("brotli-only"), ModTime: newer},
+	}
+	dir := compressionDir{dir: http.FS(fsys), identityAllowed: true}
+
+	tests := []struct {
+		name            string
+		path            string
+		encodings       []Encoding
+		identityAllowed bool
+		wantEnc         Encoding
+		wantErr         bool
+	}{
+		{
+			name:            "serves fresh pre-compressed variant",
+			path:            "style.css",
+			encodings:       []Encoding{Brotli},
+			identityAllowed: true,
+			wantEnc:         Brotli,
+		},
+		{
+			name:            "skips stale pre-compressed variant and falls back",
+			path:            "style.css",
+			encodings:       []Encoding{GZip},
+			identityAllowed: true,
+			wantEnc:         "",
+		},
+		{
+			name:            "falls back to identity when no encoding negotiated",
+			path:            "style.css",
+			encodings:       nil,
+			identityAllowed: true,
+			wantEnc:         "",
+		},
+		{
+			name:            "no raw file still serves pre-compressed variant",
+			path:            "noraw.css",
+			encodings:       []Encoding{Brotli},
+			identityAllowed: true,
+			wantEnc:         Brotli,
+		},
+		{
+			name:            "identity forbidden and no variant available errors",
+			path:            "missing.css",
+			encodings:       []Encoding{Brotli},
+			identityAllowed: false,
+			wantErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd := dir
+			cd.encodings = tt.encodings
+			cd.identityAllowed = tt.identityAllowed
+
+			f, enc, err := cd.Open(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Open(%q) succeeded, want error", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open(%q) failed: %v", tt.path, err)
+			}
+			defer f.Close()
+			if enc != tt.wantEnc {
+				t.Errorf("Open(%q) encoding = %q, want %q", tt.path, enc, tt.wantEnc)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package file
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultETagCacheSize is the maximum number of (path, size, mtime) -> ETag
+// entries kept in the in-memory LRU used by computeETag.
+const defaultETagCacheSize = 1024
+
+// fingerprintMaxAge is the Cache-Control max-age applied to requests whose
+// URL fingerprint matches the served file's ETag.
+const fingerprintMaxAge = 365 * 24 * time.Hour
+
+// fingerprintPattern matches a cache-busting hash suffix appended to a file
+// name, e.g. "app.abcdef12.js" yields base "app.js" and hash "abcdef12".
+var fingerprintPattern = regexp.MustCompile(`^(.*)\.([0-9a-fA-F]{8,64})(\.[^./]+)$`)
+
+// etagCacheKey identifies a file revision for the purpose of ETag caching.
+// scope distinguishes the ServerOptions.RootPath/FS a file was served from,
+// so that two different mounts serving same-named, same-size, same-mtime
+// files never share an ETag.
+type etagCacheKey struct {
+	scope   string
+	path    string
+	size    int64
+	modTime int64
+}
+
+// etagCache is a bounded in-memory LRU cache mapping file revisions to their
+// previously computed strong ETag, so that unchanged files are not re-hashed
+// on every request.
+type etagCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[etagCacheKey]*list.Element
+}
+
+type etagCacheEntry struct {
+	key  etagCacheKey
+	etag string
+}
+
+func newETagCache(capacity int) *etagCache {
+	return &etagCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[etagCacheKey]*list.Element),
+	}
+}
+
+func (c *etagCache) get(key etagCacheKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*etagCacheEntry).etag, true
+	}
+	return "", false
+}
+
+func (c *etagCache) add(key etagCacheKey, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*etagCacheEntry).etag = etag
+		return
+	}
+	el := c.ll.PushFront(&etagCacheEntry{key: key, etag: etag})
+	c.items[key] = el
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*etagCacheEntry).key)
+		}
+	}
+}
+
+// globalETagCache is shared by all Server/Content handlers in the process.
+var globalETagCache = newETagCache(defaultETagCacheSize)
+
+// computeETag returns a strong ETag for the given file, computed as the
+// SHA-256 hash of its content. Results are cached by scope, path, size and
+// modification time so unchanged files are not re-hashed on every request.
+// scope identifies the ServerOptions.RootPath/FS the file is served from
+// (see scopeKey), keeping the cache correct when multiple Server/Content
+// handlers with different roots happen to serve same-named, same-size,
+// same-mtime files. If the file cannot be hashed (e.g. it is not seekable),
+// a weak ETag derived from its size and modification time is returned
+// instead.
+func computeETag(scope, path string, fstat os.FileInfo, f http.File) string {
+	key := etagCacheKey{scope: scope, path: path, size: fstat.Size(), modTime: fstat.ModTime().UnixNano()}
+	if etag, ok := globalETagCache.get(key); ok {
+		return etag
+	}
+
+	etag, err := hashETag(f)
+	if err != nil {
+		etag = weakETag(fstat)
+	}
+	globalETagCache.add(key, etag)
+	return etag
+}
+
+// scopeKey returns a string that uniquely identifies the root a ServerOptions
+// serves files from: its resolved fsScope if FS is set, otherwise its
+// RootPath.
+func scopeKey(options ServerOptions) string {
+	if options.FS != nil {
+		return options.fsScope
+	}
+	return "root:" + options.RootPath
+}
+
+// fsScopeCounter hands out the identities assigned to fsScope by
+// nextFSScope. A plain counter is used instead of formatting the FS value
+// itself (e.g. via "%p") because fs.FS implementations aren't required to be
+// pointer/map/slice-backed: a value type such as embed.FS or a bare struct
+// has no meaningful pointer representation, and two distinct, equal-valued
+// instances of such a type would otherwise collide on the same scope.
+var fsScopeCounter uint64
+
+// nextFSScope returns a new scope identity, unique for the lifetime of the
+// process, to be assigned once per resolved ServerOptions.FS.
+func nextFSScope() string {
+	return fmt.Sprintf("fs:%d", atomic.AddUint64(&fsScopeCounter, 1))
+}
+
+func hashETag(f http.File) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`, nil
+}
+
+func weakETag(fstat os.FileInfo) string {
+	return fmt.Sprintf(`W/"%x-%x"`, fstat.Size(), fstat.ModTime().UnixNano())
+}
+
+// stripFingerprint removes a cache-busting hash suffix from the last path
+// segment, e.g. "/js/app.abcdef12.js" becomes "/js/app.js" with hash
+// "abcdef12". found is false if path does not carry a fingerprint suffix.
+func stripFingerprint(path string) (stripped, hash string, found bool) {
+	dir, base := filepath.Split(path)
+	m := fingerprintPattern.FindStringSubmatch(base)
+	if m == nil {
+		return path, "", false
+	}
+	return dir + m[1] + m[3], strings.ToLower(m[2]), true
+}
+
+// matchesFingerprint reports whether hash (extracted from a fingerprinted
+// URL) is a prefix of the hex digest carried by etag.
+func matchesFingerprint(etag, hash string) bool {
+	if hash == "" {
+		return false
+	}
+	v := strings.TrimPrefix(etag, "W/")
+	v = strings.Trim(v, `"`)
+	return strings.HasPrefix(strings.ToLower(v), hash)
+}
+
+// HasFingerprint reports whether path carries an asset fingerprint suffix of
+// the kind stripped by ServerOptions.AssetFingerprint, e.g.
+// "/js/app.abcdef12.js". It does not check whether the fingerprint actually
+// matches a served file's ETag.
+func HasFingerprint(path string) bool {
+	_, _, found := stripFingerprint(path)
+	return found
+}
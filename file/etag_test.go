@@ -0,0 +1,168 @@
+package file
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func openTestFile(t *testing.T, fsys fstest.MapFS, name string) (http.File, os.FileInfo) {
+	t.Helper()
+	f, err := http.FS(fsys).Open(name)
+	if err != nil {
+		t.Fatalf("Open(%q) failed: %v", name, err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		t.Fatalf("Stat(%q) failed: %v", name, err)
+	}
+	return f, st
+}
+
+func TestComputeETag_ScopedByRoot(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"a/same.txt": {Data: []byte("hello"), ModTime: modTime},
+		"b/same.txt": {Data: []byte("world"), ModTime: modTime},
+	}
+
+	fa, sta := openTestFile(t, fsys, "a/same.txt")
+	defer fa.Close()
+	fb, stb := openTestFile(t, fsys, "b/same.txt")
+	defer fb.Close()
+
+	etagA := computeETag("root:/a", "same.txt", sta, fa)
+	etagB := computeETag("root:/b", "same.txt", stb, fb)
+
+	if etagA == etagB {
+		t.Errorf("computeETag() returned the same ETag for different scopes: %v", etagA)
+	}
+}
+
+func TestComputeETag_CachedForSameScope(t *testing.T) {
+	fsys := fstest.MapFS{
+		"file.txt": {Data: []byte("content"), ModTime: time.Unix(1700000000, 0)},
+	}
+
+	f1, st1 := openTestFile(t, fsys, "file.txt")
+	defer f1.Close()
+	etag1 := computeETag("root:/x", "file.txt", st1, f1)
+
+	f2, st2 := openTestFile(t, fsys, "file.txt")
+	defer f2.Close()
+	etag2 := computeETag("root:/x", "file.txt", st2, f2)
+
+	if etag1 != etag2 {
+		t.Errorf("computeETag() not stable across calls: %v != %v", etag1, etag2)
+	}
+}
+
+// constFS is a value-typed fs.FS with no internal pointer, map, slice or
+// channel field, e.g. the same shape as embed.FS. "%p" cannot format such a
+// value meaningfully, which is exactly the case scopeKey must still get
+// right.
+type constFS struct{ name string }
+
+func (f constFS) Open(name string) (fs.File, error) { return nil, fs.ErrNotExist }
+
+func TestScopeKey(t *testing.T) {
+	t.Run("root paths", func(t *testing.T) {
+		tests := []struct {
+			name string
+			a    ServerOptions
+			b    ServerOptions
+			same bool
+		}{
+			{
+				name: "different root paths",
+				a:    ServerOptions{RootPath: "/srv/assets"},
+				b:    ServerOptions{RootPath: "/srv/uploads"},
+				same: false,
+			},
+			{
+				name: "same root path",
+				a:    ServerOptions{RootPath: "/srv/assets"},
+				b:    ServerOptions{RootPath: "/srv/assets"},
+				same: true,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got := scopeKey(tt.a) == scopeKey(tt.b)
+				if got != tt.same {
+					t.Errorf("scopeKey() equality = %v, want %v", got, tt.same)
+				}
+			})
+		}
+	})
+
+	// FS-backed options only get a meaningful, collision-free scope once
+	// resolved through getServerOptions, which is what assigns fsScope.
+	t.Run("resolved FS options never collide, even with identical value-typed FS", func(t *testing.T) {
+		a := getServerOptions([]ServerOptions{{FS: constFS{name: "shared"}}})
+		b := getServerOptions([]ServerOptions{{FS: constFS{name: "shared"}}})
+
+		if scopeKey(a) == scopeKey(b) {
+			t.Errorf("scopeKey() collided for two distinct resolutions of an equal-valued, value-typed FS: %v", scopeKey(a))
+		}
+	})
+
+	t.Run("map-backed FS instances never collide", func(t *testing.T) {
+		a := getServerOptions([]ServerOptions{{FS: fstest.MapFS{}}})
+		b := getServerOptions([]ServerOptions{{FS: fstest.MapFS{}}})
+
+		if scopeKey(a) == scopeKey(b) {
+			t.Errorf("scopeKey() collided for two distinct FS resolutions: %v", scopeKey(a))
+		}
+	})
+}
+
+func TestStripFingerprint(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		stripped string
+		hash     string
+		found    bool
+	}{
+		{"fingerprinted", "/js/app.abcdef12.js", "/js/app.js", "abcdef12", true},
+		{"no fingerprint", "/js/app.js", "/js/app.js", "", false},
+		{"short suffix not a fingerprint", "/js/app.1.js", "/js/app.1.js", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped, hash, found := stripFingerprint(tt.path)
+			if stripped != tt.stripped || hash != tt.hash || found != tt.found {
+				t.Errorf("stripFingerprint(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.path, stripped, hash, found, tt.stripped, tt.hash, tt.found)
+			}
+		})
+	}
+}
+
+func TestMatchesFingerprint(t *testing.T) {
+	tests := []struct {
+		name string
+		etag string
+		hash string
+		want bool
+	}{
+		{"strong etag prefix match", `"abcdef1234567890"`, "abcdef12", true},
+		{"weak etag prefix match", `W/"abcdef1234567890"`, "abcdef12", true},
+		{"mismatch", `"1234567890abcdef"`, "abcdef12", false},
+		{"empty hash", `"abcdef1234567890"`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesFingerprint(tt.etag, tt.hash); got != tt.want {
+				t.Errorf("matchesFingerprint(%q, %q) = %v, want %v", tt.etag, tt.hash, got, tt.want)
+			}
+		})
+	}
+}
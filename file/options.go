@@ -27,11 +27,42 @@ type ServerOptions struct {
 	// If false, a 404 HTTP error will be returned by the handler.
 	Allow func(*routing.Context, string) bool
 	// Define available compression encodings for serving files. Encodings are negotiated against the
-	// unser agent. The first encoding which matches the accepted encodings from the user agent as well
-	// as is available as file is served to the client.
+	// user agent's "Accept-Encoding" header, including its quality values (e.g. "br;q=0.9, gzip;q=0.5"),
+	// and the highest-quality encoding that is both accepted and available as a pre-compressed file
+	// (named "<file>.<encoding>") is served to the client. A "*;q=0" (or "identity;q=0") entry without
+	// a more specific match for a given encoding disables it.
 	Compression []Encoding
 	// The FS to be used to serve files from. When set, this overrides RootPath.
+	// Any fs.FS implementation works, including embed.FS and fstest.MapFS, as
+	// well as pre-compressed variants opened through Compression.
 	FS fs.FS
+	// AutoIndex enables rendering a directory listing (as an HTML table, or as
+	// JSON if the request's "Accept" header prefers "application/json") when a
+	// directory is requested and IndexFile is not set (or does not match).
+	AutoIndex bool
+	// SignedURL, when set, requires requests to carry a valid signature
+	// produced by Sign, rejecting any request that doesn't with a 403 HTTP
+	// error. Use this to hand out short-lived download links for private
+	// assets without wrapping the handler in custom middleware.
+	SignedURL *SignedURLOptions
+	// ETag enables computation of a strong "ETag" header from a hash of the
+	// served file's content (falling back to a weak ETag derived from size
+	// and modification time if the file cannot be hashed). When enabled,
+	// conditional requests using "If-None-Match"/"If-Match" are honored by
+	// the underlying http.ServeContent call.
+	ETag bool
+	// AssetFingerprint enables the cache-busting pattern where a hash suffix
+	// in the URL (e.g. "/js/app.abcdef12.js") is stripped before the file
+	// lookup. When the stripped hash matches the served file's ETag, the
+	// response is sent with a far-future "Cache-Control: public, max-age=...,
+	// immutable" header. Requires ETag to also be enabled.
+	AssetFingerprint bool
+
+	// fsScope identifies this resolved options' FS for ETag cache scoping
+	// (see scopeKey). It is assigned once by getServerOptions and must not be
+	// set directly, since fs.FS implementations aren't guaranteed to carry a
+	// stable, comparable identity of their own (e.g. a value-typed FS).
+	fsScope string
 }
 
 // Merge takes another instance of ServerOptions and merges it with the current instance.
@@ -58,6 +89,18 @@ func (t ServerOptions) Merge(other ServerOptions) (new ServerOptions) {
 	if other.FS != nil {
 		new.FS = other.FS
 	}
+	if other.ETag {
+		new.ETag = true
+	}
+	if other.AssetFingerprint {
+		new.AssetFingerprint = true
+	}
+	if other.AutoIndex {
+		new.AutoIndex = true
+	}
+	if other.SignedURL != nil {
+		new.SignedURL = other.SignedURL
+	}
 
 	return new
 }
@@ -73,5 +116,9 @@ func getServerOptions(opts []ServerOptions) ServerOptions {
 		options.RootPath = filepath.Join(RootPath, options.RootPath)
 	}
 
+	if options.FS != nil {
+		options.fsScope = nextFSScope()
+	}
+
 	return options
 }
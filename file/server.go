@@ -6,10 +6,12 @@
 package file
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	routing "github.com/go-ozzo/ozzo-routing/v2"
@@ -18,8 +20,10 @@ import (
 type Encoding string
 
 const (
-	Brotli = Encoding("br")
-	GZip   = Encoding("gzip")
+	Brotli  = Encoding("br")
+	GZip    = Encoding("gzip")
+	Zstd    = Encoding("zstd")
+	Deflate = Encoding("deflate")
 )
 
 // PathMap specifies the mapping between URL paths (keys) and file paths (keys).
@@ -59,8 +63,9 @@ func Server(pathMap PathMap, opts ...ServerOptions) routing.Handler {
 
 	from, to := parsePathMap(pathMap)
 
-	// security measure: limit the files within options.RootPath
-	dir := http.Dir(options.RootPath)
+	// security measure: limit the files within options.RootPath (or options.FS)
+	dir := fileSystem(options)
+	scope := scopeKey(options)
 
 	return func(c *routing.Context) error {
 		if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
@@ -76,6 +81,19 @@ func Server(pathMap PathMap, opts ...ServerOptions) routing.Handler {
 			return routing.NewHTTPError(http.StatusNotFound)
 		}
 
+		if options.SignedURL != nil {
+			if err := checkSignedURL(c, c.Request.URL.Path, *options.SignedURL); err != nil {
+				return err
+			}
+		}
+
+		fingerprint := ""
+		if options.AssetFingerprint {
+			if stripped, hash, ok := stripFingerprint(path); ok {
+				path, fingerprint = stripped, hash
+			}
+		}
+
 		var (
 			file  http.File
 			fstat os.FileInfo
@@ -83,12 +101,18 @@ func Server(pathMap PathMap, opts ...ServerOptions) routing.Handler {
 			enc   Encoding
 		)
 
-		encodings := negotiateEncodings(c, options.Compression)
-		dir := compressionDir{dir, encodings}
+		if len(options.Compression) > 0 {
+			c.Response.Header().Set("Vary", "Accept-Encoding")
+		}
+		encodings, identityAllowed := negotiateEncodings(c, options.Compression)
+		dir := compressionDir{dir, encodings, identityAllowed}
 
 		if file, enc, err = dir.Open(path); err != nil {
+			if err == errIdentityNotAcceptable {
+				return routing.NewHTTPError(http.StatusNotAcceptable)
+			}
 			if options.CatchAllFile != "" {
-				return serveFile(c, dir, options.CatchAllFile)
+				return serveFile(c, dir, options.CatchAllFile, options)
 			}
 			return routing.NewHTTPError(http.StatusNotFound, err.Error())
 		}
@@ -99,23 +123,43 @@ func Server(pathMap PathMap, opts ...ServerOptions) routing.Handler {
 		}
 
 		if fstat.IsDir() {
-			if options.IndexFile == "" {
-				return routing.NewHTTPError(http.StatusNotFound)
+			if options.IndexFile != "" {
+				indexPath := filepath.Join(path, options.IndexFile)
+				if !options.AutoIndex {
+					return serveFile(c, dir, indexPath, options)
+				}
+				if index, _, err := dir.Open(indexPath); err == nil {
+					index.Close()
+					return serveFile(c, dir, indexPath, options)
+				}
+			}
+			if options.AutoIndex {
+				return serveAutoIndex(c, file, path)
 			}
-			return serveFile(c, dir, filepath.Join(path, options.IndexFile))
+			return routing.NewHTTPError(http.StatusNotFound)
 		}
 
 		if enc != "" {
 			c.Response.Header().Set("Content-Encoding", string(enc))
 		}
+		if options.ETag {
+			etag := computeETag(scope, encodedPath(path, enc), fstat, file)
+			c.Response.Header().Set("ETag", etag)
+			if matchesFingerprint(etag, fingerprint) {
+				c.Response.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int64(fingerprintMaxAge.Seconds())))
+			}
+		}
 		http.ServeContent(c.Response, c.Request, path, fstat.ModTime(), file)
 		return nil
 	}
 }
 
-func serveFile(c *routing.Context, dir compressionDir, path string) error {
+func serveFile(c *routing.Context, dir compressionDir, path string, options ServerOptions) error {
 	file, enc, err := dir.Open(path)
 	if err != nil {
+		if err == errIdentityNotAcceptable {
+			return routing.NewHTTPError(http.StatusNotAcceptable)
+		}
 		return routing.NewHTTPError(http.StatusNotFound, err.Error())
 	}
 	defer file.Close()
@@ -130,10 +174,23 @@ func serveFile(c *routing.Context, dir compressionDir, path string) error {
 	if enc != "" {
 		c.Response.Header().Set("Content-Encoding", string(enc))
 	}
+	if options.ETag {
+		c.Response.Header().Set("ETag", computeETag(scopeKey(options), encodedPath(path, enc), fstat, file))
+	}
 	http.ServeContent(c.Response, c.Request, path, fstat.ModTime(), file)
 	return nil
 }
 
+// encodedPath returns path suffixed with the negotiated encoding, so that
+// cached ETags for distinct pre-compressed variants of the same file never
+// collide.
+func encodedPath(path string, enc Encoding) string {
+	if enc == "" {
+		return path
+	}
+	return path + "." + string(enc)
+}
+
 // Content returns a handler that serves the content of the specified file as the response.
 // The file to be served can be specified as an absolute file path or a path relative to RootPath (which
 // defaults to the current working path).
@@ -143,11 +200,14 @@ func serveFile(c *routing.Context, dir compressionDir, path string) error {
 func Content(path string, opts ...ServerOptions) routing.Handler {
 	options := getServerOptions(opts)
 
-	var dir http.Dir
-	if filepath.IsAbs(path) {
+	var dir http.FileSystem
+	switch {
+	case options.FS != nil:
+		dir = http.FS(options.FS)
+	case filepath.IsAbs(path):
 		dir = http.Dir(path)
-		path = ""
-	} else {
+		options.RootPath, path = path, ""
+	default:
 		dir = http.Dir(options.RootPath)
 	}
 
@@ -160,13 +220,31 @@ func Content(path string, opts ...ServerOptions) routing.Handler {
 			return routing.NewHTTPError(http.StatusBadRequest, "invalid URL path")
 		}
 
-		encodings := negotiateEncodings(c, options.Compression)
-		dir := compressionDir{dir, encodings}
+		if options.SignedURL != nil {
+			if err := checkSignedURL(c, c.Request.URL.Path, *options.SignedURL); err != nil {
+				return err
+			}
+		}
+
+		if len(options.Compression) > 0 {
+			c.Response.Header().Set("Vary", "Accept-Encoding")
+		}
+		encodings, identityAllowed := negotiateEncodings(c, options.Compression)
+		dir := compressionDir{dir, encodings, identityAllowed}
 
-		return serveFile(c, dir, path)
+		return serveFile(c, dir, path, options)
 	}
 }
 
+// fileSystem returns the http.FileSystem files should be served from,
+// preferring options.FS over options.RootPath when both are usable.
+func fileSystem(options ServerOptions) http.FileSystem {
+	if options.FS != nil {
+		return http.FS(options.FS)
+	}
+	return http.Dir(options.RootPath)
+}
+
 func parsePathMap(pathMap PathMap) (from, to []string) {
 	from = make([]string, len(pathMap))
 	to = make([]string, len(pathMap))
@@ -192,23 +270,99 @@ func matchPath(path string, from, to []string) (string, bool) {
 	return "", false
 }
 
-func negotiateEncodings(c *routing.Context, available []Encoding) []Encoding {
+// negotiateEncodings parses the request's Accept-Encoding header per RFC
+// 7231 Section 5.3.4 and returns the subset of available encodings the
+// client accepts, ordered from most to least preferred. identityAllowed
+// reports whether the unencoded representation may be served as a fallback;
+// it is false only when the client explicitly forbids identity (e.g.
+// "identity;q=0" or "*;q=0" without a more specific identity entry).
+func negotiateEncodings(c *routing.Context, available []Encoding) (encodings []Encoding, identityAllowed bool) {
 	if len(available) == 0 {
-		return nil
+		return nil, true
+	}
+
+	header := c.Request.Header.Get("Accept-Encoding")
+	if header == "" {
+		return nil, true
+	}
+
+	weights := make(map[string]float64)
+	wildcardQ, hasWildcard := -1.0, false
+	for _, entry := range strings.Split(header, ",") {
+		enc, q, ok := parseWeightedEncoding(entry)
+		if !ok {
+			continue
+		}
+		if enc == "*" {
+			wildcardQ, hasWildcard = q, true
+			continue
+		}
+		weights[enc] = q
 	}
 
-	negotioated := make([]Encoding, 0, len(available))
+	identityAllowed = true
+	if q, ok := weights["identity"]; ok {
+		identityAllowed = q > 0
+	} else if hasWildcard {
+		identityAllowed = wildcardQ > 0
+	}
 
-	acceptEncodings := strings.Split(c.Request.Header.Get("Accept-Encoding"), ",")
-	for _, availEnc := range available {
-		for _, accEnc := range acceptEncodings {
-			if string(availEnc) == strings.TrimSpace(strings.ToLower(accEnc)) {
-				negotioated = append(negotioated, availEnc)
+	type candidate struct {
+		enc Encoding
+		q   float64
+	}
+	candidates := make([]candidate, 0, len(available))
+	for _, enc := range available {
+		q, ok := weights[string(enc)]
+		if !ok {
+			if !hasWildcard {
+				continue
 			}
+			q = wildcardQ
+		}
+		if q <= 0 {
+			continue
 		}
+		candidates = append(candidates, candidate{enc, q})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	encodings = make([]Encoding, len(candidates))
+	for i, cand := range candidates {
+		encodings[i] = cand.enc
+	}
+	return encodings, identityAllowed
+}
+
+// parseWeightedEncoding parses a single "token" or "token;q=value" entry of
+// an Accept-Encoding header. ok is false if entry is empty or malformed.
+func parseWeightedEncoding(entry string) (name string, q float64, ok bool) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return "", 0, false
+	}
+
+	q = 1
+	if idx := strings.IndexByte(entry, ';'); idx >= 0 {
+		name = strings.TrimSpace(entry[:idx])
+		for _, param := range strings.Split(entry[idx+1:], ";") {
+			param = strings.TrimSpace(param)
+			val, found := strings.CutPrefix(param, "q=")
+			if !found {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(val, 64); err == nil {
+				q = parsed
+			}
+		}
+	} else {
+		name = entry
 	}
 
-	return negotioated
+	return strings.ToLower(name), q, name != ""
 }
 
 // Equivalent to containsDotDot() check in http.ServeFile()
@@ -0,0 +1,151 @@
+package file
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+)
+
+func newTestContext(req *http.Request) (*routing.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	return routing.NewContext(rec, req), rec
+}
+
+func TestNegotiateEncodings(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		available      []Encoding
+		wantEncodings  []Encoding
+		wantIdentityOK bool
+	}{
+		{
+			name:           "no header accepts anything",
+			header:         "",
+			available:      []Encoding{Brotli, GZip},
+			wantEncodings:  nil,
+			wantIdentityOK: true,
+		},
+		{
+			name:           "no compression configured",
+			header:         "br, gzip",
+			available:      nil,
+			wantEncodings:  nil,
+			wantIdentityOK: true,
+		},
+		{
+			name:           "quality values pick the highest first",
+			header:         "gzip;q=0.5, br;q=0.9",
+			available:      []Encoding{Brotli, GZip},
+			wantEncodings:  []Encoding{Brotli, GZip},
+			wantIdentityOK: true,
+		},
+		{
+			name:           "unlisted encoding ignored",
+			header:         "zstd;q=1.0",
+			available:      []Encoding{Brotli, GZip},
+			wantEncodings:  nil,
+			wantIdentityOK: true,
+		},
+		{
+			name:           "wildcard covers unlisted encoding",
+			header:         "*;q=0.8",
+			available:      []Encoding{Brotli},
+			wantEncodings:  []Encoding{Brotli},
+			wantIdentityOK: true,
+		},
+		{
+			name:           "zero quality excludes encoding",
+			header:         "br;q=0",
+			available:      []Encoding{Brotli, GZip},
+			wantEncodings:  nil,
+			wantIdentityOK: true,
+		},
+		{
+			name:           "identity explicitly forbidden",
+			header:         "br, identity;q=0",
+			available:      []Encoding{Brotli},
+			wantEncodings:  []Encoding{Brotli},
+			wantIdentityOK: false,
+		},
+		{
+			name:           "wildcard zero forbids identity",
+			header:         "*;q=0, br;q=1",
+			available:      []Encoding{Brotli},
+			wantEncodings:  []Encoding{Brotli},
+			wantIdentityOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Accept-Encoding", tt.header)
+			}
+			c, _ := newTestContext(req)
+
+			gotEncodings, gotIdentityOK := negotiateEncodings(c, tt.available)
+			if len(gotEncodings) != len(tt.wantEncodings) {
+				t.Fatalf("negotiateEncodings() encodings = %v, want %v", gotEncodings, tt.wantEncodings)
+			}
+			for i := range gotEncodings {
+				if gotEncodings[i] != tt.wantEncodings[i] {
+					t.Errorf("negotiateEncodings() encodings = %v, want %v", gotEncodings, tt.wantEncodings)
+					break
+				}
+			}
+			if gotIdentityOK != tt.wantIdentityOK {
+				t.Errorf("negotiateEncodings() identityAllowed = %v, want %v", gotIdentityOK, tt.wantIdentityOK)
+			}
+		})
+	}
+}
+
+func TestParseWeightedEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  string
+		name2  string
+		q      float64
+		wantOK bool
+	}{
+		{"plain token", "br", "br", 1, true},
+		{"with quality", " gzip;q=0.3 ", "gzip", 0.3, true},
+		{"mixed case", "BR", "br", 1, true},
+		{"empty", "   ", "", 0, false},
+		{"malformed quality kept at default", "br;q=notanumber", "br", 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, q, ok := parseWeightedEncoding(tt.entry)
+			if name != tt.name2 || q != tt.q || ok != tt.wantOK {
+				t.Errorf("parseWeightedEncoding(%q) = (%q, %v, %v), want (%q, %v, %v)",
+					tt.entry, name, q, ok, tt.name2, tt.q, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEncodedPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		enc  Encoding
+		want string
+	}{
+		{"no encoding", "/js/app.js", "", "/js/app.js"},
+		{"with encoding", "/js/app.js", Brotli, "/js/app.js.br"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodedPath(tt.path, tt.enc); got != tt.want {
+				t.Errorf("encodedPath(%q, %q) = %q, want %q", tt.path, tt.enc, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package file
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+)
+
+const (
+	sigParam    = "sig"
+	expParam    = "exp"
+	ipParam     = "ip"
+	methodParam = "method"
+)
+
+// SignedURLOptions enables verifying links produced by Sign before a file is
+// served. A request is rejected with a 403 HTTP error unless it carries a
+// "sig" query parameter matching the HMAC-SHA256 signature computed over the
+// canonical request, a non-expired "exp" query parameter, and, if present in
+// the link, a matching "ip"/"method" query parameter. Only these four query
+// parameters are ever inspected, so any other query parameter a client,
+// proxy or CDN adds to the URL (e.g. a cache-busting "?v=2") does not affect
+// verification.
+type SignedURLOptions struct {
+	// Secret is the HMAC-SHA256 key links are signed and verified with.
+	Secret []byte
+}
+
+// SignOptions configures the link produced by Sign.
+type SignOptions struct {
+	// Secret is the HMAC-SHA256 key to sign the link with. It must match the
+	// Secret configured in the ServerOptions.SignedURL that will verify it.
+	Secret []byte
+	// IP, if set, pins the link to requests whose remote IP matches it.
+	IP string
+	// Method, if set, pins the link to requests using this HTTP method.
+	Method string
+}
+
+// Sign returns path with an expiring, HMAC-SHA256 signed query string
+// appended (e.g. "/private/report.pdf?exp=...&sig=..."), verified by a
+// file.Server or file.Content handler configured with a matching
+// ServerOptions.SignedURL. The link expires ttl from now.
+func Sign(path string, ttl time.Duration, opts SignOptions) string {
+	exp := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	sig := signCanonicalRequest(path, exp, opts.IP, opts.Method, opts.Secret)
+
+	values := url.Values{}
+	values.Set(expParam, exp)
+	if opts.IP != "" {
+		values.Set(ipParam, opts.IP)
+	}
+	if opts.Method != "" {
+		values.Set(methodParam, opts.Method)
+	}
+	values.Set(sigParam, sig)
+	return path + "?" + values.Encode()
+}
+
+// checkSignedURL verifies that the request carries a valid, non-expired
+// signature for path, as produced by Sign with a matching secret. Query
+// parameters other than "sig", "exp", "ip" and "method" are ignored, so they
+// cannot invalidate an otherwise valid link.
+func checkSignedURL(c *routing.Context, path string, options SignedURLOptions) error {
+	query := c.Request.URL.Query()
+
+	sig := query.Get(sigParam)
+	if sig == "" {
+		return routing.NewHTTPError(http.StatusForbidden, "missing signature")
+	}
+
+	exp := query.Get(expParam)
+	ip := query.Get(ipParam)
+	method := query.Get(methodParam)
+
+	expected := signCanonicalRequest(path, exp, ip, method, options.Secret)
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return routing.NewHTTPError(http.StatusForbidden, "invalid signature")
+	}
+
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expUnix {
+		return routing.NewHTTPError(http.StatusForbidden, "expired signature")
+	}
+
+	if ip != "" && ip != requestIP(c.Request) {
+		return routing.NewHTTPError(http.StatusForbidden, "client IP mismatch")
+	}
+
+	if method != "" && !strings.EqualFold(method, c.Request.Method) {
+		return routing.NewHTTPError(http.StatusForbidden, "method mismatch")
+	}
+
+	return nil
+}
+
+// signCanonicalRequest computes the HMAC-SHA256 signature, as a hex string,
+// of the canonical request: path followed by its "exp", "ip" and "method"
+// values (the only parameters Sign ever sets, omitting "ip"/"method" when
+// unset). No other query parameter is part of the canonical request.
+func signCanonicalRequest(path, exp, ip, method string, secret []byte) string {
+	var sb strings.Builder
+	sb.WriteString(path)
+	fmt.Fprintf(&sb, "\n%s=%s", expParam, exp)
+	if ip != "" {
+		fmt.Fprintf(&sb, "\n%s=%s", ipParam, ip)
+	}
+	if method != "" {
+		fmt.Fprintf(&sb, "\n%s=%s", methodParam, method)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(sb.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// requestIP returns the request's remote IP, stripped of its port.
+func requestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
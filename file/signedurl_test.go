@@ -0,0 +1,100 @@
+package file
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndCheckSignedURL(t *testing.T) {
+	secret := []byte("top-secret")
+	const path = "/private/report.pdf"
+
+	sign := func(opts SignOptions) (query string) {
+		signed := Sign(path, time.Hour, opts)
+		return signed[len(path)+1:]
+	}
+
+	check := func(query, remoteAddr, method string) error {
+		req := httptest.NewRequest(method, path+"?"+query, nil)
+		req.RemoteAddr = remoteAddr
+		c, _ := newTestContext(req)
+		return checkSignedURL(c, path, SignedURLOptions{Secret: secret})
+	}
+
+	t.Run("valid link accepted", func(t *testing.T) {
+		query := sign(SignOptions{Secret: secret})
+		if err := check(query, "203.0.113.1:1234", "GET"); err != nil {
+			t.Errorf("checkSignedURL() = %v, want nil", err)
+		}
+	})
+
+	t.Run("extra query parameter does not invalidate link", func(t *testing.T) {
+		query := sign(SignOptions{Secret: secret}) + "&v=2"
+		if err := check(query, "203.0.113.1:1234", "GET"); err != nil {
+			t.Errorf("checkSignedURL() = %v, want nil", err)
+		}
+	})
+
+	t.Run("tampered signature rejected", func(t *testing.T) {
+		query := sign(SignOptions{Secret: secret})
+		last := "0"
+		if strings.HasSuffix(query, "0") {
+			last = "1"
+		}
+		tampered := query[:len(query)-1] + last
+		if err := check(tampered, "203.0.113.1:1234", "GET"); err == nil {
+			t.Error("checkSignedURL() = nil, want error")
+		}
+	})
+
+	t.Run("wrong secret rejected", func(t *testing.T) {
+		query := sign(SignOptions{Secret: []byte("wrong-secret")})
+		if err := check(query, "203.0.113.1:1234", "GET"); err == nil {
+			t.Error("checkSignedURL() = nil, want error")
+		}
+	})
+
+	t.Run("expired link rejected", func(t *testing.T) {
+		signed := Sign(path, -time.Minute, SignOptions{Secret: secret})
+		query := signed[len(path)+1:]
+		if err := check(query, "203.0.113.1:1234", "GET"); err == nil {
+			t.Error("checkSignedURL() = nil, want error")
+		}
+	})
+
+	t.Run("matching IP accepted", func(t *testing.T) {
+		query := sign(SignOptions{Secret: secret, IP: "203.0.113.1"})
+		if err := check(query, "203.0.113.1:1234", "GET"); err != nil {
+			t.Errorf("checkSignedURL() = %v, want nil", err)
+		}
+	})
+
+	t.Run("mismatched IP rejected", func(t *testing.T) {
+		query := sign(SignOptions{Secret: secret, IP: "203.0.113.1"})
+		if err := check(query, "198.51.100.2:1234", "GET"); err == nil {
+			t.Error("checkSignedURL() = nil, want error")
+		}
+	})
+
+	t.Run("matching method accepted", func(t *testing.T) {
+		query := sign(SignOptions{Secret: secret, Method: "GET"})
+		if err := check(query, "203.0.113.1:1234", "GET"); err != nil {
+			t.Errorf("checkSignedURL() = %v, want nil", err)
+		}
+	})
+
+	t.Run("mismatched method rejected", func(t *testing.T) {
+		query := sign(SignOptions{Secret: secret, Method: "GET"})
+		if err := check(query, "203.0.113.1:1234", "POST"); err == nil {
+			t.Error("checkSignedURL() = nil, want error")
+		}
+	})
+
+	t.Run("missing signature rejected", func(t *testing.T) {
+		if err := check("exp=9999999999", "203.0.113.1:1234", "GET"); err == nil {
+			t.Error("checkSignedURL() = nil, want error")
+		}
+	})
+}